@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command spec-gen writes the dashboard's OpenAPI/Swagger spec to stdout
+// without standing up a server, so it can be checked into docs or fed to
+// client generators. It mirrors the spec generator shipped with
+// tektoncd/pipeline.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	restful "github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	spec "github.com/go-openapi/spec"
+	"github.com/tektoncd/dashboard/pkg/endpoints"
+)
+
+func main() {
+	version := flag.String("version", endpoints.Version, "dashboard API version to embed in the spec")
+	apiVersion := flag.String("apiVersion", "v1", "Kubernetes API version of the resources served by the dashboard")
+	flag.Parse()
+
+	container := restful.NewContainer()
+	r := endpoints.Resource{}
+	r.RegisterEndpoints(container)
+	r.RegisterWebsocket(container)
+
+	config := restfulspec.Config{
+		WebServices: container.RegisteredWebServices(),
+		APIPath:     "/swagger.json",
+	}
+	swagger := restfulspec.BuildSwagger(config)
+	if swagger.Info == nil {
+		swagger.Info = &spec.Info{}
+	}
+	swagger.Info.Version = *version
+	swagger.Info.Extensions = map[string]interface{}{"x-kubernetes-api-version": *apiVersion}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(swagger); err != nil {
+		log.Fatalf("failed to encode swagger spec: %v", err)
+	}
+}
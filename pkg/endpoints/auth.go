@@ -0,0 +1,246 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	restful "github.com/emicklei/go-restful"
+	logging "github.com/tektoncd/dashboard/pkg/logging"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+// AuthMode - the supported --auth-mode values
+type AuthMode string
+
+const (
+	// AuthModeNone - no authentication or authorization is performed
+	AuthModeNone AuthMode = "none"
+	// AuthModeToken - requests must carry a bearer token validated via TokenReview/SubjectAccessReview
+	AuthModeToken AuthMode = "token"
+	// AuthModeOIDC - like AuthModeToken, plus the dashboard advertises OIDC discovery metadata
+	AuthModeOIDC AuthMode = "oidc"
+)
+
+var (
+	// AuthModeFlag - selects the auth subsystem behaviour
+	AuthModeFlag = flag.String("auth-mode", string(AuthModeNone), "Authentication/authorization mode: none, token or oidc")
+	// OIDCIssuerFlag - the issuer advertised at /.well-known/oauth-authorization-server
+	OIDCIssuerFlag = flag.String("oidc-issuer", "", "OIDC issuer URL, required when --auth-mode=oidc")
+	// ImpersonationFlag - whether handlers should rebuild their clients to impersonate the caller
+	ImpersonationFlag = flag.Bool("impersonation", false, "Impersonate the authenticated caller when talking to the Kubernetes/Pipeline APIs")
+)
+
+// AuthConfig - authentication/authorization settings threaded into each
+// handler via Resource
+type AuthConfig struct {
+	Mode          AuthMode
+	OIDCIssuer    string
+	Impersonation bool
+}
+
+// NewAuthConfigFromFlags - builds an AuthConfig from the --auth-mode family
+// of flags; call after flag.Parse()
+func NewAuthConfigFromFlags() AuthConfig {
+	return AuthConfig{
+		Mode:          AuthMode(*AuthModeFlag),
+		OIDCIssuer:    *OIDCIssuerFlag,
+		Impersonation: *ImpersonationFlag,
+	}
+}
+
+// AuthFilter - returns a restful.FilterFunction that authenticates the
+// caller's bearer token via TokenReview, authorizes the request via
+// SubjectAccessReview against the namespace/resource/verb implied by the
+// route, and, when configured, rebuilds r's clients to impersonate the
+// caller for the remainder of the request
+func (r Resource) AuthFilter() restful.FilterFunction {
+	return func(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+		if r.AuthConfig.Mode == AuthModeNone {
+			chain.ProcessFilter(request, response)
+			return
+		}
+
+		token := bearerToken(request)
+		if token == "" {
+			response.WriteErrorString(401, "missing bearer token")
+			return
+		}
+
+		review, err := r.K8sClient.AuthenticationV1().TokenReviews().Create(&authnv1.TokenReview{
+			Spec: authnv1.TokenReviewSpec{Token: token},
+		})
+		if err != nil || !review.Status.Authenticated {
+			logging.Log.Errorf("token review failed: %s", err)
+			response.WriteErrorString(401, "invalid bearer token")
+			return
+		}
+
+		namespace := request.PathParameter("namespace")
+		resource, verb := resourceAndVerbForRoute(request)
+		sar, err := r.K8sClient.AuthorizationV1().SubjectAccessReviews().Create(&authzv1.SubjectAccessReview{
+			Spec: authzv1.SubjectAccessReviewSpec{
+				User:   review.Status.User.Username,
+				Groups: review.Status.User.Groups,
+				ResourceAttributes: &authzv1.ResourceAttributes{
+					Namespace: namespace,
+					Resource:  resource,
+					Verb:      verb,
+				},
+			},
+		})
+		if err != nil || !sar.Status.Allowed {
+			response.WriteErrorString(403, "forbidden")
+			return
+		}
+
+		handler := r
+		if r.AuthConfig.Impersonation {
+			impersonated, err := handler.impersonate(review.Status.User)
+			if err != nil {
+				logging.Log.Errorf("could not impersonate %s: %s", review.Status.User.Username, err)
+				response.WriteErrorString(500, "impersonation failed")
+				return
+			}
+			handler = impersonated
+		}
+
+		request.SetAttribute("resource", handler)
+		chain.ProcessFilter(request, response)
+	}
+}
+
+// impersonate - returns a copy of r whose PipelineClient/K8sClient are
+// rebuilt from r.Config impersonating user, carrying through the shared
+// informers unchanged (they're a read-only cluster-wide cache kept in sync
+// by the dashboard's own ServiceAccount, not per-caller)
+func (r Resource) impersonate(user authnv1.UserInfo) (Resource, error) {
+	if r.Config == nil {
+		return Resource{}, fmt.Errorf("no base rest.Config to impersonate from")
+	}
+	config := rest.CopyConfig(r.Config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user.Username,
+		Groups:   user.Groups,
+	}
+	impersonated, err := NewResourceFromConfig(config, r.AuthConfig)
+	if err != nil {
+		return Resource{}, err
+	}
+	impersonated.Informers = r.Informers
+	impersonated.InformersSynced = r.InformersSynced
+	return impersonated, nil
+}
+
+// resourceFromRequest - returns the impersonated Resource AuthFilter stashed
+// on request via SetAttribute, falling back to r when impersonation is
+// disabled (or AuthFilter never ran, e.g. --auth-mode=none). Every handler
+// bound to a route at registration time closes over the original r, so this
+// is how impersonation actually reaches them.
+func (r Resource) resourceFromRequest(request *restful.Request) Resource {
+	if impersonated, ok := request.Attribute("resource").(Resource); ok {
+		return impersonated
+	}
+	return r
+}
+
+// bearerToken - extracts the bearer token from the Authorization header
+func bearerToken(request *restful.Request) string {
+	header := request.Request.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+// routeResources - maps the fixed path segment of each registered route to
+// the Kubernetes/Tekton resource name a SubjectAccessReview needs, so RBAC
+// Role/ClusterRole rules written against real resource types actually apply
+var routeResources = map[string]string{
+	"pipeline":         "pipelines",
+	"pipelinerun":      "pipelineruns",
+	"pipelineresource": "pipelineresources",
+	"task":             "tasks",
+	"taskrun":          "taskruns",
+	"log":              "pods/log",
+	"taskrunlog":       "pods/log",
+	"pipelinerunlog":   "pods/log",
+	"credentials":      "secrets",
+	"extensions":       "services",
+}
+
+// resourceAndVerbForRoute - derives the Kubernetes resource/verb pair a
+// route implies, for the SubjectAccessReview
+func resourceAndVerbForRoute(request *restful.Request) (resource, verb string) {
+	resource = "unknown"
+	for _, segment := range strings.Split(request.SelectedRoutePath(), "/") {
+		if mapped, ok := routeResources[segment]; ok {
+			resource = mapped
+			break
+		}
+	}
+	switch request.Request.Method {
+	case "GET":
+		verb = "get"
+	case "POST":
+		verb = "create"
+	case "PUT":
+		verb = "update"
+	case "DELETE":
+		verb = "delete"
+	default:
+		verb = "get"
+	}
+	return resource, verb
+}
+
+// OAuthMetadata - the subset of RFC 8414 authorization server metadata the
+// dashboard UI needs to start an OIDC login
+type OAuthMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// RegisterOAuthMetadata - exposes /.well-known/oauth-authorization-server
+// when running with --auth-mode=oidc
+func (r Resource) RegisterOAuthMetadata(container *restful.Container) {
+	if r.AuthConfig.Mode != AuthModeOIDC {
+		return
+	}
+	logging.Log.Info("Adding API for OAuth authorization server metadata")
+	ws := new(restful.WebService)
+	ws.
+		Path("/.well-known/oauth-authorization-server").
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.GET("").To(func(request *restful.Request, response *restful.Response) {
+		issuer := r.AuthConfig.OIDCIssuer
+		response.WriteEntity(OAuthMetadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: issuer + "/protocol/openid-connect/auth",
+			TokenEndpoint:         issuer + "/protocol/openid-connect/token",
+		})
+	}).
+		Doc("getOAuthMetadata").
+		Operation("getOAuthMetadata").
+		Writes(OAuthMetadata{}))
+
+	container.Add(ws)
+}
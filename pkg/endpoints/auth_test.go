@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/rest"
+)
+
+// reactToAuth wires the fake K8sClient's TokenReview/SubjectAccessReview
+// Create calls to fixed results, the way a real API server's responses
+// would drive AuthFilter.
+func reactToAuth(authenticated, allowed bool) *k8sfake.Clientset {
+	client := k8sfake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &authnv1.TokenReview{
+			Status: authnv1.TokenReviewStatus{
+				Authenticated: authenticated,
+				User:          authnv1.UserInfo{Username: "alice"},
+			},
+		}, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &authzv1.SubjectAccessReview{
+			Status: authzv1.SubjectAccessReviewStatus{Allowed: allowed},
+		}, nil
+	})
+	return client
+}
+
+// noopChain builds a restful.FilterChain whose Target records whether the
+// filter let the request through.
+func noopChain() (*restful.FilterChain, *bool) {
+	called := false
+	return &restful.FilterChain{
+		Target: func(*restful.Request, *restful.Response) { called = true },
+	}, &called
+}
+
+func TestAuthFilterRejectsMissingBearerToken(t *testing.T) {
+	r := Resource{
+		K8sClient:  reactToAuth(true, true),
+		AuthConfig: AuthConfig{Mode: AuthModeToken},
+	}
+
+	httpReq := httptest.NewRequest("GET", "/v1/namespaces/default/pipeline", nil)
+	rec := httptest.NewRecorder()
+	req := restful.NewRequest(httpReq)
+	resp := restful.NewResponse(rec)
+	chain, called := noopChain()
+
+	r.AuthFilter()(req, resp, chain)
+
+	if rec.Code != 401 {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+	if *called {
+		t.Fatal("filter chain was processed despite the missing bearer token")
+	}
+}
+
+func TestAuthFilterForbidsWhenSARDenies(t *testing.T) {
+	r := Resource{
+		K8sClient:  reactToAuth(true, false),
+		AuthConfig: AuthConfig{Mode: AuthModeToken},
+	}
+
+	httpReq := httptest.NewRequest("GET", "/v1/namespaces/default/pipeline", nil)
+	httpReq.Header.Set("Authorization", "Bearer sometoken")
+	rec := httptest.NewRecorder()
+	req := restful.NewRequest(httpReq)
+	resp := restful.NewResponse(rec)
+	chain, called := noopChain()
+
+	r.AuthFilter()(req, resp, chain)
+
+	if rec.Code != 403 {
+		t.Fatalf("got status %d, want 403", rec.Code)
+	}
+	if *called {
+		t.Fatal("filter chain was processed despite the denied SubjectAccessReview")
+	}
+}
+
+func TestResourceAndVerbForRoute(t *testing.T) {
+	cases := []struct {
+		routePath    string
+		method       string
+		wantResource string
+		wantVerb     string
+	}{
+		{"/{namespace}/pipeline", "GET", "pipelines", "get"},
+		{"/{namespace}/pipeline/{name}", "GET", "pipelines", "get"},
+		{"/{namespace}/credentials/{id}", "DELETE", "secrets", "delete"},
+		{"", "GET", "services", "get"},
+	}
+
+	for _, c := range cases {
+		httpReq := httptest.NewRequest(c.method, "/ignored", nil)
+		req := restful.NewRequest(httpReq)
+		routePath := c.routePath
+		if routePath == "" {
+			routePath = "/v1/extensions"
+		}
+		req.SetSelectedRoutePath(routePath)
+
+		resource, verb := resourceAndVerbForRoute(req)
+		if resource != c.wantResource || verb != c.wantVerb {
+			t.Errorf("resourceAndVerbForRoute(%q, %s) = (%q, %q), want (%q, %q)",
+				routePath, c.method, resource, verb, c.wantResource, c.wantVerb)
+		}
+	}
+}
+
+func TestImpersonateChangesClientIdentity(t *testing.T) {
+	base, err := NewResourceFromConfig(&rest.Config{Host: "https://example.invalid"}, AuthConfig{Impersonation: true})
+	if err != nil {
+		t.Fatalf("NewResourceFromConfig: %s", err)
+	}
+	base.Informers = &informers{}
+	base.InformersSynced = func() bool { return true }
+
+	impersonated, err := base.impersonate(authnv1.UserInfo{Username: "alice", Groups: []string{"devs"}})
+	if err != nil {
+		t.Fatalf("impersonate: %s", err)
+	}
+
+	if impersonated.Config.Impersonate.UserName != "alice" {
+		t.Fatalf("got impersonated username %q, want %q", impersonated.Config.Impersonate.UserName, "alice")
+	}
+	if base.Config.Impersonate.UserName != "" {
+		t.Fatal("impersonate mutated the base Resource's Config instead of copying it")
+	}
+	if impersonated.PipelineClient == base.PipelineClient || impersonated.K8sClient == base.K8sClient {
+		t.Fatal("impersonate did not rebuild the clients with the impersonated identity")
+	}
+	if impersonated.Informers != base.Informers {
+		t.Fatal("impersonate should carry the shared informer cache through unchanged")
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	restful "github.com/emicklei/go-restful"
+	logging "github.com/tektoncd/dashboard/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// extensionLabel - services carrying this label are registered as extensions
+const extensionLabel = "tekton-dashboard-extension=true"
+
+// extensionEndpointsKey - annotation holding the comma-separated list of
+// subpaths the extension wants registered under /v1/extensions/{service}/
+const extensionEndpointsKey = "tekton-dashboard-endpoints"
+
+// extensionDisplayNameKey - annotation holding the human-readable name shown
+// in the dashboard UI for this extension
+const extensionDisplayNameKey = "tekton-dashboard-display-name"
+
+// extensionBundleLocationKey - annotation holding the location of the UI
+// bundle the dashboard should load for this extension
+const extensionBundleLocationKey = "tekton-dashboard-bundle-location"
+
+// extensionPortKey - annotation naming the service port the proxy should use;
+// falls back to Spec.Ports[0] when absent
+const extensionPortKey = "tekton-dashboard-port"
+
+var extensionVerbs = []string{"GET", "POST", "PUT", "DELETE"}
+
+// Extension - a discovered extension service, and the UI metadata needed to
+// surface it in the dashboard's extension picker
+type Extension struct {
+	Name           string `json:"name"`
+	DisplayName    string `json:"displayname"`
+	BundleLocation string `json:"bundlelocation"`
+	URL            string `json:"url"`
+	Port           string `json:"port"`
+}
+
+// ExtensionList - the body of GET /v1/extensions
+type ExtensionList struct {
+	Extensions []Extension `json:"extensions"`
+}
+
+// RegisterExtension - discovers the extensions currently in namespace and
+// registers them as REST API extensions, then starts an informer so that
+// extensions added, updated or removed later are reflected without
+// restarting the dashboard
+func (r Resource) RegisterExtension(container *restful.Container, namespace string) {
+	logging.Log.Info("Adding API for extensions")
+
+	extensions := map[string]Extension{}
+	var mu sync.RWMutex
+	r.rebuildExtensionWebService(container, &mu, extensions)
+
+	watchlist := cache.NewListWatchFromClient(
+		r.K8sClient.CoreV1().RESTClient(),
+		"services",
+		namespace,
+		fields.Everything(),
+	)
+	lw := filterByExtensionLabel(watchlist)
+	_, informer := cache.NewInformer(lw, &corev1.Service{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.onExtensionChange(container, &mu, extensions, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			r.onExtensionChange(container, &mu, extensions, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if svc, ok := obj.(*corev1.Service); ok {
+				mu.Lock()
+				delete(extensions, svc.ObjectMeta.Name)
+				mu.Unlock()
+				r.rebuildExtensionWebService(container, &mu, extensions)
+			}
+		},
+	})
+
+	stopCh := make(chan struct{})
+	go informer.Run(stopCh)
+}
+
+// filterByExtensionLabel - narrows a ListWatch down to services labelled
+// with extensionLabel, since cache.NewListWatchFromClient has no selector
+// argument of its own
+func filterByExtensionLabel(lw *cache.ListWatch) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = extensionLabel
+			return lw.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = extensionLabel
+			return lw.Watch(options)
+		},
+	}
+}
+
+// onExtensionChange - recomputes the Extension entry for svc and rebuilds
+// the extension WebService so the new routes take effect immediately
+func (r Resource) onExtensionChange(container *restful.Container, mu *sync.RWMutex, extensions map[string]Extension, obj interface{}) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	annotations := svc.ObjectMeta.Annotations
+	mu.Lock()
+	extensions[svc.ObjectMeta.Name] = Extension{
+		Name:           svc.ObjectMeta.Name,
+		DisplayName:    annotations[extensionDisplayNameKey],
+		BundleLocation: annotations[extensionBundleLocationKey],
+		URL:            annotations[extensionEndpointsKey],
+		Port:           getPort(*svc),
+	}
+	mu.Unlock()
+	r.rebuildExtensionWebService(container, mu, extensions)
+}
+
+// rebuildExtensionWebService - tears down and re-registers the "/" extension
+// WebService from scratch, so it always reflects the current extensions map.
+// extensions is mutated from the informer's event goroutine while this and
+// the "GET /v1/extensions" closure below read it from HTTP-serving
+// goroutines, so every access goes through mu.
+func (r Resource) rebuildExtensionWebService(container *restful.Container, mu *sync.RWMutex, extensions map[string]Extension) {
+	if existing := container.RegisteredWebServices(); existing != nil {
+		for _, ws := range existing {
+			if ws.RootPath() == "/v1/extensions" {
+				container.Remove(ws)
+			}
+		}
+	}
+
+	ws := new(restful.WebService)
+	ws.
+		Path("/v1/extensions").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON).
+		Filter(r.AuthFilter())
+
+	ws.Route(ws.GET("").To(func(request *restful.Request, response *restful.Response) {
+		mu.RLock()
+		defer mu.RUnlock()
+		list := ExtensionList{Extensions: []Extension{}}
+		for _, ext := range extensions {
+			list.Extensions = append(list.Extensions, ext)
+		}
+		response.WriteEntity(list)
+	}).
+		Doc("getAllExtensions").
+		Operation("getAllExtensions").
+		Writes(ExtensionList{}))
+
+	mu.RLock()
+	snapshot := make([]Extension, 0, len(extensions))
+	for _, ext := range extensions {
+		snapshot = append(snapshot, ext)
+	}
+	mu.RUnlock()
+
+	for _, ext := range snapshot {
+		for _, subpath := range strings.Split(ext.URL, ",") {
+			subpath = strings.TrimSpace(subpath)
+			if subpath == "" {
+				continue
+			}
+			route := "/" + ext.Name + "/" + strings.TrimPrefix(subpath, "/")
+			for _, verb := range extensionVerbs {
+				ws.Route(ws.Method(verb).Path(route+"/{subpath:*}").To(ext.HandleExtension).
+					Doc("handleExtension:" + ext.Name).
+					Operation("handleExtension" + verb + ext.Name))
+			}
+		}
+	}
+
+	container.Add(ws)
+}
+
+// HandleExtension - proxies the request through to the extension's service,
+// on the port discovered for it, after stripping the "/v1/extensions/{name}"
+// prefix the dashboard registered the route under, so the extension sees
+// the path it itself serves (e.g. "/foo/...", not "/v1/extensions/ext/foo/...")
+func (ext Extension) HandleExtension(request *restful.Request, response *restful.Response) {
+	target, err := url.Parse("http://" + ext.Name + ":" + ext.Port + "/")
+	if err != nil {
+		logging.Log.Errorf("could not parse extension target for %s: %s", ext.Name, err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	prefix := "/v1/extensions/" + ext.Name
+	request.Request.URL.Path = strings.TrimPrefix(request.Request.URL.Path, prefix)
+	if request.Request.URL.RawPath != "" {
+		request.Request.URL.RawPath = strings.TrimPrefix(request.Request.URL.RawPath, prefix)
+	}
+	if request.Request.URL.Path == "" {
+		request.Request.URL.Path = "/"
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ServeHTTP(response, request.Request)
+}
+
+// getPort - returns the extension's chosen port, honouring extensionPortKey
+// when set, falling back to the service's first declared port
+func getPort(svc corev1.Service) string {
+	if name, ok := svc.ObjectMeta.Annotations[extensionPortKey]; ok {
+		for _, p := range svc.Spec.Ports {
+			if p.Name == name {
+				return strconv.Itoa(int(p.Port))
+			}
+		}
+	}
+	return strconv.Itoa(int(svc.Spec.Ports[0].Port))
+}
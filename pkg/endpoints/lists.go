@@ -0,0 +1,208 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	pipelineinformers "github.com/tektoncd/pipeline/pkg/client/informers/externalversions"
+	pipelinelisters "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// listOpts - the labelSelector/fieldSelector/limit/continue/orderBy query
+// parameters shared by every getAll* list handler
+type listOpts struct {
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+	limit         int
+	continueToken string
+	orderBy       string
+}
+
+// paginatedResponse - a typed page of results, matching the shape
+// Kubernetes list responses use for continuation
+type paginatedResponse struct {
+	Items               interface{} `json:"items"`
+	Continue            string      `json:"continue,omitempty"`
+	RemainingItemCount   *int64     `json:"remainingItemCount,omitempty"`
+}
+
+// listOptsFromRequest - parses the list query parameters shared across
+// getAll* handlers
+func listOptsFromRequest(request *restful.Request) (listOpts, error) {
+	opts := listOpts{
+		orderBy:       request.QueryParameter("orderBy"),
+		continueToken: request.QueryParameter("continue"),
+	}
+
+	selector := request.QueryParameter("labelSelector")
+	if selector == "" {
+		opts.labelSelector = labels.Everything()
+	} else {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return listOpts{}, err
+		}
+		opts.labelSelector = parsed
+	}
+
+	if selector := request.QueryParameter("fieldSelector"); selector == "" {
+		opts.fieldSelector = fields.Everything()
+	} else {
+		parsed, err := fields.ParseSelector(selector)
+		if err != nil {
+			return listOpts{}, err
+		}
+		opts.fieldSelector = parsed
+	}
+
+	if limit := request.QueryParameter("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return listOpts{}, err
+		}
+		opts.limit = parsed
+	}
+
+	return opts, nil
+}
+
+// sortKey - the field paginate sorts, pages and builds continue tokens by,
+// for the given orderBy ("creationTimestamp", or name if empty/unrecognised)
+func sortKey(item metav1.Object, orderBy string) string {
+	if orderBy == "creationTimestamp" {
+		return item.GetCreationTimestamp().Time.UTC().Format(time.RFC3339)
+	}
+	return item.GetName()
+}
+
+// matchesFieldSelector - evaluates a fieldSelector against the fields of
+// item that listers don't support natively; only metadata.name/
+// metadata.namespace are indexed today, mirroring what the Kubernetes API
+// server itself supports for most custom resources
+func matchesFieldSelector(item metav1.Object, selector fields.Selector) bool {
+	if selector == nil || selector.Empty() {
+		return true
+	}
+	return selector.Matches(fields.Set{
+		"metadata.name":      item.GetName(),
+		"metadata.namespace": item.GetNamespace(),
+	})
+}
+
+// paginate - applies fieldSelector, orders items by name or creation
+// timestamp, applies the continue/limit window, and reports how many items
+// remain, mirroring Kubernetes list pagination semantics
+func paginate(items []metav1.Object, opts listOpts) (page []metav1.Object, continueToken string, remaining int64) {
+	filtered := items[:0:0]
+	for _, item := range items {
+		if matchesFieldSelector(item, opts.fieldSelector) {
+			filtered = append(filtered, item)
+		}
+	}
+	items = filtered
+
+	sort.Slice(items, func(i, j int) bool {
+		return sortKey(items[i], opts.orderBy) < sortKey(items[j], opts.orderBy)
+	})
+
+	start := 0
+	if opts.continueToken != "" {
+		// Default to "exhausted": if no item sorts after the token (a
+		// cursor from an already fully-consumed list, or the set shrank),
+		// the page is empty rather than silently restarting from the top.
+		start = len(items)
+		for i, item := range items {
+			if sortKey(item, opts.orderBy) > opts.continueToken {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := len(items)
+	if opts.limit > 0 && start+opts.limit < end {
+		end = start + opts.limit
+	}
+
+	page = items[start:end]
+	remaining = int64(len(items) - end)
+	if end < len(items) {
+		continueToken = sortKey(items[end-1], opts.orderBy)
+	}
+	return page, continueToken, remaining
+}
+
+// informers - the shared informer factories started once at dashboard boot
+// and read from by every getAll* handler, instead of each issuing a live List
+type informers struct {
+	pipelineFactory pipelineinformers.SharedInformerFactory
+	pipelineLister  pipelinelisters.PipelineLister
+	pipelineRunLister pipelinelisters.PipelineRunLister
+	pipelineResourceLister pipelinelisters.PipelineResourceLister
+	taskLister      pipelinelisters.TaskLister
+	taskRunLister   pipelinelisters.TaskRunLister
+}
+
+// StartInformers - builds the shared informer factory for pipelineClient and
+// starts it, returning immediately rather than blocking for the caches to
+// sync, alongside a HasSynced func the /readiness handler can poll live.
+// Call once at dashboard boot, before serving traffic, and assign the result
+// to Resource.Informers.
+func StartInformers(pipelineClient versioned.Interface, stopCh <-chan struct{}) (*informers, func() bool) {
+	factory := pipelineinformers.NewSharedInformerFactory(pipelineClient, 30*time.Second)
+
+	pipelines := factory.Tekton().V1alpha1().Pipelines()
+	pipelineRuns := factory.Tekton().V1alpha1().PipelineRuns()
+	pipelineResources := factory.Tekton().V1alpha1().PipelineResources()
+	tasks := factory.Tekton().V1alpha1().Tasks()
+	taskRuns := factory.Tekton().V1alpha1().TaskRuns()
+
+	inf := &informers{
+		pipelineFactory:        factory,
+		pipelineLister:         pipelines.Lister(),
+		pipelineRunLister:      pipelineRuns.Lister(),
+		pipelineResourceLister: pipelineResources.Lister(),
+		taskLister:             tasks.Lister(),
+		taskRunLister:          taskRuns.Lister(),
+	}
+
+	factory.Start(stopCh)
+
+	hasSynced := func() bool {
+		return pipelines.Informer().HasSynced() &&
+			pipelineRuns.Informer().HasSynced() &&
+			pipelineResources.Informer().HasSynced() &&
+			tasks.Informer().HasSynced() &&
+			taskRuns.Informer().HasSynced()
+	}
+	return inf, hasSynced
+}
+
+// checkReadiness - reports ready only once the shared informers have synced,
+// so the dashboard doesn't serve list traffic against an empty cache
+func (r Resource) checkReadiness(request *restful.Request, response *restful.Response) {
+	if r.InformersSynced != nil && !r.InformersSynced() {
+		response.WriteErrorString(503, "informers not yet synced")
+		return
+	}
+	response.WriteHeader(200)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+func namedItems(names ...string) []metav1.Object {
+	items := make([]metav1.Object, len(names))
+	for i, name := range names {
+		items[i] = &metav1.ObjectMeta{Name: name}
+	}
+	return items
+}
+
+func itemNames(items []metav1.Object) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.GetName()
+	}
+	return names
+}
+
+func TestPaginateOrdersByNameByDefault(t *testing.T) {
+	items := namedItems("c", "a", "b")
+
+	page, continueToken, remaining := paginate(items, listOpts{})
+
+	got := itemNames(page)
+	want := []string{"a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got order %v, want %v", got, want)
+		}
+	}
+	if continueToken != "" || remaining != 0 {
+		t.Fatalf("expected no continuation for a full page, got continue=%q remaining=%d", continueToken, remaining)
+	}
+}
+
+func TestPaginateLimitAndContinue(t *testing.T) {
+	items := namedItems("c", "a", "b")
+
+	page, continueToken, remaining := paginate(items, listOpts{limit: 2})
+
+	if got := itemNames(page); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("got page %v, want [a b]", got)
+	}
+	if continueToken != "b" {
+		t.Fatalf("got continue token %q, want %q", continueToken, "b")
+	}
+	if remaining != 1 {
+		t.Fatalf("got remaining %d, want 1", remaining)
+	}
+
+	page, continueToken, remaining = paginate(items, listOpts{limit: 2, continueToken: continueToken})
+	if got := itemNames(page); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("got second page %v, want [c]", got)
+	}
+	if continueToken != "" || remaining != 0 {
+		t.Fatalf("expected no continuation after the last page, got continue=%q remaining=%d", continueToken, remaining)
+	}
+}
+
+// TestPaginateStaleContinueTokenReturnsEmptyPage guards against the
+// off-by-default-zero bug: a continue token that no longer matches any item
+// (the set shrank, or the cursor was already fully consumed) must yield an
+// empty page, not silently restart from the top.
+func TestPaginateStaleContinueTokenReturnsEmptyPage(t *testing.T) {
+	items := namedItems("a", "b")
+
+	page, continueToken, remaining := paginate(items, listOpts{continueToken: "z"})
+
+	if len(page) != 0 {
+		t.Fatalf("got page %v, want empty page for an exhausted continue token", itemNames(page))
+	}
+	if continueToken != "" || remaining != 0 {
+		t.Fatalf("got continue=%q remaining=%d, want both zero", continueToken, remaining)
+	}
+}
+
+func TestPaginateOrdersByCreationTimestamp(t *testing.T) {
+	older := &metav1.ObjectMeta{Name: "z", CreationTimestamp: metav1.NewTime(time.Unix(100, 0))}
+	newer := &metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.NewTime(time.Unix(200, 0))}
+	items := []metav1.Object{newer, older}
+
+	page, _, _ := paginate(items, listOpts{orderBy: "creationTimestamp"})
+
+	if got := itemNames(page); len(got) != 2 || got[0] != "z" || got[1] != "a" {
+		t.Fatalf("got order %v, want [z a] (oldest first)", got)
+	}
+}
+
+// TestPaginateCreationTimestampContinueToken guards against using a
+// name-keyed continue token while ordering by creationTimestamp: the
+// returned token, and the comparison on the next page, must both be keyed
+// on the field actually being sorted by.
+func TestPaginateCreationTimestampContinueToken(t *testing.T) {
+	first := &metav1.ObjectMeta{Name: "z", CreationTimestamp: metav1.NewTime(time.Unix(100, 0))}
+	second := &metav1.ObjectMeta{Name: "a", CreationTimestamp: metav1.NewTime(time.Unix(200, 0))}
+	items := []metav1.Object{first, second}
+
+	page, continueToken, _ := paginate(items, listOpts{orderBy: "creationTimestamp", limit: 1})
+	if got := itemNames(page); len(got) != 1 || got[0] != "z" {
+		t.Fatalf("got first page %v, want [z]", got)
+	}
+
+	page, _, _ = paginate(items, listOpts{orderBy: "creationTimestamp", limit: 1, continueToken: continueToken})
+	if got := itemNames(page); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("got second page %v, want [a]", got)
+	}
+}
+
+func TestPaginateFieldSelector(t *testing.T) {
+	items := namedItems("a", "b", "c")
+	selector, err := fields.ParseSelector("metadata.name=b")
+	if err != nil {
+		t.Fatalf("ParseSelector: %s", err)
+	}
+
+	page, _, remaining := paginate(items, listOpts{fieldSelector: selector})
+
+	if got := itemNames(page); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("got page %v, want [b]", got)
+	}
+	if remaining != 0 {
+		t.Fatalf("got remaining %d, want 0", remaining)
+	}
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	logging "github.com/tektoncd/dashboard/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logLine - a single multiplexed log line, tagged with the pod/container it
+// came from so that fanned-in PipelineRun streams can be demultiplexed
+// client-side
+type logLine struct {
+	PodName       string    `json:"podName"`
+	ContainerName string    `json:"containerName"`
+	Timestamp     time.Time `json:"ts"`
+	Line          string    `json:"line"`
+}
+
+var logUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// podLogOptionsFromRequest - parses follow/sinceTime/sinceSeconds/tailLines/
+// timestamps/previous/container query parameters into a corev1.PodLogOptions
+func podLogOptionsFromRequest(request *restful.Request) (*corev1.PodLogOptions, error) {
+	opts := &corev1.PodLogOptions{
+		Container:  request.QueryParameter("container"),
+		Follow:     request.QueryParameter("follow") == "true",
+		Timestamps: request.QueryParameter("timestamps") == "true",
+		Previous:   request.QueryParameter("previous") == "true",
+	}
+
+	if since := request.QueryParameter("sinceTime"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, err
+		}
+		metaTime := metav1.NewTime(t)
+		opts.SinceTime = &metaTime
+	}
+
+	if since := request.QueryParameter("sinceSeconds"); since != "" {
+		seconds, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts.SinceSeconds = &seconds
+	}
+
+	if tail := request.QueryParameter("tailLines"); tail != "" {
+		lines, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts.TailLines = &lines
+	}
+
+	return opts, nil
+}
+
+// getPodLog - streams a single pod's log, upgrading to a WebSocket when the
+// client asks for one, otherwise writing chunked plain HTTP
+func (r Resource) getPodLog(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	opts, err := podLogOptionsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(request.Request.Context())
+	defer cancel()
+
+	stream, err := r.K8sClient.CoreV1().Pods(namespace).GetLogs(name, opts).Stream()
+	if err != nil {
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+	defer stream.Close()
+
+	r.streamPodLog(ctx, request, response, name, opts.Container, stream)
+}
+
+// getTaskRunLog - resolves the TaskRun's pod and streams its log
+func (r Resource) getTaskRunLog(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	taskRun, err := r.PipelineClient.TektonV1alpha1().TaskRuns(namespace).Get(name, metav1.GetOptions{})
+	if err != nil || taskRun.Status.PodName == "" {
+		response.WriteErrorString(404, "no pod found for TaskRun "+name)
+		return
+	}
+
+	opts, err := podLogOptionsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(request.Request.Context())
+	defer cancel()
+
+	stream, err := r.K8sClient.CoreV1().Pods(namespace).GetLogs(taskRun.Status.PodName, opts).Stream()
+	if err != nil {
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+	defer stream.Close()
+
+	r.streamPodLog(ctx, request, response, taskRun.Status.PodName, opts.Container, stream)
+}
+
+// getPipelineRunLog - fans in every TaskRun pod's stream for a PipelineRun
+// into a single multiplexed {podName, containerName, ts, line} stream
+func (r Resource) getPipelineRunLog(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	ctx, cancel := context.WithCancel(request.Request.Context())
+	defer cancel()
+
+	pipelineRun, err := r.PipelineClient.TektonV1alpha1().PipelineRuns(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+
+	opts, err := podLogOptionsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	lines := make(chan logLine)
+	// done is buffered to taskRunCount so that streamTaskRunIntoChannel's
+	// deferred send never blocks: if the select below returns early via
+	// ctx.Done() (client disconnect), nothing is left reading done, and an
+	// unbuffered channel would leak every still-running goroutine
+	done := make(chan struct{}, len(pipelineRun.Status.TaskRuns))
+	taskRunCount := len(pipelineRun.Status.TaskRuns)
+
+	for taskRunName := range pipelineRun.Status.TaskRuns {
+		go r.streamTaskRunIntoChannel(ctx, namespace, taskRunName, *opts, lines, done)
+	}
+
+	upgrade := websocket.IsWebSocketUpgrade(request.Request)
+	var conn *websocket.Conn
+	if upgrade {
+		conn, err = logUpgrader.Upgrade(response.ResponseWriter, request.Request, nil)
+		if err != nil {
+			logging.Log.Errorf("could not upgrade pipelinerun log stream: %s", err)
+			return
+		}
+		defer conn.Close()
+	} else {
+		response.ResponseWriter.Header().Set("Transfer-Encoding", "chunked")
+		response.WriteHeader(200)
+	}
+
+	finished := 0
+	for finished < taskRunCount {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			finished++
+		case line := <-lines:
+			encoded, _ := json.Marshal(line)
+			if upgrade {
+				if err := conn.WriteMessage(websocket.TextMessage, encoded); err != nil {
+					return
+				}
+			} else {
+				response.Write(append(encoded, '\n'))
+				response.Flush()
+			}
+		}
+	}
+}
+
+// streamTaskRunIntoChannel - streams one TaskRun's pod log into lines until
+// the pod's log ends, the context is cancelled, or an error occurs
+func (r Resource) streamTaskRunIntoChannel(ctx context.Context, namespace, taskRunName string, opts corev1.PodLogOptions, lines chan<- logLine, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+
+	taskRun, err := r.PipelineClient.TektonV1alpha1().TaskRuns(namespace).Get(taskRunName, metav1.GetOptions{})
+	if err != nil || taskRun.Status.PodName == "" {
+		return
+	}
+	podName := taskRun.Status.PodName
+
+	stream, err := r.K8sClient.CoreV1().Pods(namespace).GetLogs(podName, &opts).Stream()
+	if err != nil {
+		logging.Log.Errorf("could not stream logs for pod %s: %s", podName, err)
+		return
+	}
+	defer stream.Close()
+
+	// GetLogs().Stream() isn't context-aware, so scanner.Scan() below can
+	// block indefinitely on a quiet, still-following container; closing
+	// stream on ctx.Done() is what actually unblocks it on disconnect
+	go func() {
+		<-ctx.Done()
+		stream.Close()
+	}()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return
+		case lines <- logLine{PodName: podName, ContainerName: opts.Container, Timestamp: time.Now(), Line: scanner.Text()}:
+		}
+	}
+}
+
+// streamPodLog - writes a single pod's log stream to response, as a
+// WebSocket when requested, otherwise as chunked plain HTTP. GetLogs().
+// Stream() isn't context-aware, so scanner.Scan() below can block
+// indefinitely on a quiet, still-following container; closing stream (and
+// conn, once upgraded) on ctx.Done() is what actually unblocks the read and
+// ends the loop on client disconnect, rather than a check that only runs
+// between already-completed reads.
+func (r Resource) streamPodLog(ctx context.Context, request *restful.Request, response *restful.Response, podName, containerName string, stream io.ReadCloser) {
+	go func() {
+		<-ctx.Done()
+		stream.Close()
+	}()
+
+	scanner := bufio.NewScanner(stream)
+
+	if websocket.IsWebSocketUpgrade(request.Request) {
+		conn, err := logUpgrader.Upgrade(response.ResponseWriter, request.Request, nil)
+		if err != nil {
+			logging.Log.Errorf("could not upgrade pod log stream: %s", err)
+			return
+		}
+		defer conn.Close()
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+		for scanner.Scan() {
+			if err := conn.WriteMessage(websocket.TextMessage, scanner.Bytes()); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	response.ResponseWriter.Header().Set("Transfer-Encoding", "chunked")
+	response.WriteHeader(200)
+	for scanner.Scan() {
+		response.Write(scanner.Bytes())
+		response.Write([]byte("\n"))
+		response.Flush()
+	}
+}
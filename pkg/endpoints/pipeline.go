@@ -0,0 +1,302 @@
+/*
+Copyright 2019 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	restful "github.com/emicklei/go-restful"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	logging "github.com/tektoncd/dashboard/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getAllPipelines - lists Pipelines in namespace from the shared informer
+// cache, honouring labelSelector/limit/continue/orderBy
+func (r Resource) getAllPipelines(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	opts, err := listOptsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	all, err := r.Informers.pipelineLister.Pipelines(namespace).List(opts.labelSelector)
+	if err != nil {
+		logging.Log.Errorf("could not list pipelines: %s", err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	objects := make([]metav1.Object, len(all))
+	byName := make(map[string]*pipelinev1alpha1.Pipeline, len(all))
+	for i, p := range all {
+		objects[i] = p
+		byName[p.GetName()] = p
+	}
+
+	page, continueToken, remaining := paginate(objects, opts)
+	items := make([]pipelinev1alpha1.Pipeline, len(page))
+	for i, obj := range page {
+		items[i] = *byName[obj.GetName()]
+	}
+
+	response.WriteEntity(paginatedResponse{
+		Items:              pipelinev1alpha1.PipelineList{Items: items},
+		Continue:           continueToken,
+		RemainingItemCount: &remaining,
+	})
+}
+
+// getPipeline - fetches a single Pipeline from the shared informer cache
+func (r Resource) getPipeline(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	pipeline, err := r.Informers.pipelineLister.Pipelines(namespace).Get(name)
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+	response.WriteEntity(pipeline)
+}
+
+// getAllPipelineRuns - lists PipelineRuns in namespace from the shared
+// informer cache, honouring labelSelector/limit/continue/orderBy
+func (r Resource) getAllPipelineRuns(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	opts, err := listOptsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	all, err := r.Informers.pipelineRunLister.PipelineRuns(namespace).List(opts.labelSelector)
+	if err != nil {
+		logging.Log.Errorf("could not list pipelineruns: %s", err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	objects := make([]metav1.Object, len(all))
+	byName := make(map[string]*pipelinev1alpha1.PipelineRun, len(all))
+	for i, p := range all {
+		objects[i] = p
+		byName[p.GetName()] = p
+	}
+
+	page, continueToken, remaining := paginate(objects, opts)
+	items := make([]pipelinev1alpha1.PipelineRun, len(page))
+	for i, obj := range page {
+		items[i] = *byName[obj.GetName()]
+	}
+
+	response.WriteEntity(paginatedResponse{
+		Items:              pipelinev1alpha1.PipelineRunList{Items: items},
+		Continue:           continueToken,
+		RemainingItemCount: &remaining,
+	})
+}
+
+// getPipelineRun - fetches a single PipelineRun from the shared informer
+// cache
+func (r Resource) getPipelineRun(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	pipelineRun, err := r.Informers.pipelineRunLister.PipelineRuns(namespace).Get(name)
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+	response.WriteEntity(pipelineRun)
+}
+
+// updatePipelineRun - applies the caller's changes via a live Update against
+// the API server; informers are read-only, so writes always go direct
+func (r Resource) updatePipelineRun(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	pipelineRun := pipelinev1alpha1.PipelineRun{}
+	if err := request.ReadEntity(&pipelineRun); err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	updated, err := r.PipelineClient.TektonV1alpha1().PipelineRuns(namespace).Update(&pipelineRun)
+	if err != nil {
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+	response.WriteEntity(updated)
+}
+
+// getAllPipelineResources - lists PipelineResources in namespace from the
+// shared informer cache, honouring labelSelector/limit/continue/orderBy
+func (r Resource) getAllPipelineResources(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	opts, err := listOptsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	all, err := r.Informers.pipelineResourceLister.PipelineResources(namespace).List(opts.labelSelector)
+	if err != nil {
+		logging.Log.Errorf("could not list pipelineresources: %s", err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	objects := make([]metav1.Object, len(all))
+	byName := make(map[string]*pipelinev1alpha1.PipelineResource, len(all))
+	for i, p := range all {
+		objects[i] = p
+		byName[p.GetName()] = p
+	}
+
+	page, continueToken, remaining := paginate(objects, opts)
+	items := make([]pipelinev1alpha1.PipelineResource, len(page))
+	for i, obj := range page {
+		items[i] = *byName[obj.GetName()]
+	}
+
+	response.WriteEntity(paginatedResponse{
+		Items:              pipelinev1alpha1.PipelineResourceList{Items: items},
+		Continue:           continueToken,
+		RemainingItemCount: &remaining,
+	})
+}
+
+// getPipelineResource - fetches a single PipelineResource from the shared
+// informer cache
+func (r Resource) getPipelineResource(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	resource, err := r.Informers.pipelineResourceLister.PipelineResources(namespace).Get(name)
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+	response.WriteEntity(resource)
+}
+
+// getAllTasks - lists Tasks in namespace from the shared informer cache,
+// honouring labelSelector/limit/continue/orderBy
+func (r Resource) getAllTasks(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	opts, err := listOptsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	all, err := r.Informers.taskLister.Tasks(namespace).List(opts.labelSelector)
+	if err != nil {
+		logging.Log.Errorf("could not list tasks: %s", err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	objects := make([]metav1.Object, len(all))
+	byName := make(map[string]*pipelinev1alpha1.Task, len(all))
+	for i, t := range all {
+		objects[i] = t
+		byName[t.GetName()] = t
+	}
+
+	page, continueToken, remaining := paginate(objects, opts)
+	items := make([]pipelinev1alpha1.Task, len(page))
+	for i, obj := range page {
+		items[i] = *byName[obj.GetName()]
+	}
+
+	response.WriteEntity(paginatedResponse{
+		Items:              pipelinev1alpha1.TaskList{Items: items},
+		Continue:           continueToken,
+		RemainingItemCount: &remaining,
+	})
+}
+
+// getTask - fetches a single Task from the shared informer cache
+func (r Resource) getTask(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	task, err := r.Informers.taskLister.Tasks(namespace).Get(name)
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+	response.WriteEntity(task)
+}
+
+// getAllTaskRuns - lists TaskRuns in namespace from the shared informer
+// cache, honouring labelSelector/limit/continue/orderBy
+func (r Resource) getAllTaskRuns(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	opts, err := listOptsFromRequest(request)
+	if err != nil {
+		response.WriteErrorString(400, err.Error())
+		return
+	}
+
+	all, err := r.Informers.taskRunLister.TaskRuns(namespace).List(opts.labelSelector)
+	if err != nil {
+		logging.Log.Errorf("could not list taskruns: %s", err)
+		response.WriteErrorString(500, err.Error())
+		return
+	}
+
+	objects := make([]metav1.Object, len(all))
+	byName := make(map[string]*pipelinev1alpha1.TaskRun, len(all))
+	for i, t := range all {
+		objects[i] = t
+		byName[t.GetName()] = t
+	}
+
+	page, continueToken, remaining := paginate(objects, opts)
+	items := make([]pipelinev1alpha1.TaskRun, len(page))
+	for i, obj := range page {
+		items[i] = *byName[obj.GetName()]
+	}
+
+	response.WriteEntity(paginatedResponse{
+		Items:              pipelinev1alpha1.TaskRunList{Items: items},
+		Continue:           continueToken,
+		RemainingItemCount: &remaining,
+	})
+}
+
+// getTaskRun - fetches a single TaskRun from the shared informer cache
+func (r Resource) getTaskRun(request *restful.Request, response *restful.Response) {
+	r = r.resourceFromRequest(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	taskRun, err := r.Informers.taskRunLister.TaskRuns(namespace).Get(name)
+	if err != nil {
+		response.WriteErrorString(404, err.Error())
+		return
+	}
+	response.WriteEntity(taskRun)
+}
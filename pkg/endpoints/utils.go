@@ -14,26 +14,51 @@ limitations under the License.
 package endpoints
 
 import (
-	"strconv"
-	"net/http/httputil"
-	"net/url"
+	"net/http"
 	restful "github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	spec "github.com/go-openapi/spec"
 	logging "github.com/tektoncd/dashboard/pkg/logging"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	k8sclientset "k8s.io/client-go/kubernetes"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
 )
 
-// extensionLabel - service with this extensionLabel is registered as extension
-const extensionLabel =  "tekton-dashboard-extension=true"  
-// urlKey - extension path is specified by the annotation with the urlKey 
-const urlKey = "tekton-dashboard-endpoints"
+// Version - the dashboard API version, surfaced in the generated OpenAPI spec
+const Version = "v1"
 
 // Resource - stores all types here that are reused throughout files
 type Resource struct {
 	PipelineClient versioned.Interface
 	K8sClient      k8sclientset.Interface
+	AuthConfig     AuthConfig
+	Informers      *informers
+	InformersSynced func() bool
+	// Config - the rest.Config the clients above were built from; kept
+	// around so AuthFilter can impersonate a caller from the dashboard's
+	// own config (in-cluster or kubeconfig) rather than assuming in-cluster
+	Config *rest.Config
+}
+
+// NewResourceFromConfig - builds a Resource whose clients talk to the
+// cluster described by config, carrying through the given AuthConfig; used
+// to construct the per-request impersonated Resource in AuthFilter
+func NewResourceFromConfig(config *rest.Config, authConfig AuthConfig) (Resource, error) {
+	pipelineClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		return Resource{}, err
+	}
+	k8sClient, err := k8sclientset.NewForConfig(config)
+	if err != nil {
+		return Resource{}, err
+	}
+	return Resource{
+		PipelineClient: pipelineClient,
+		K8sClient:      k8sClient,
+		AuthConfig:     authConfig,
+		Config:         config,
+	}, nil
 }
 
 // RegisterEndpoints - this registers our actual endpoints!
@@ -42,39 +67,210 @@ func (r Resource) RegisterEndpoints(container *restful.Container) {
 	wsv1.
 		Path("/v1/namespaces").
 		Consumes(restful.MIME_JSON).
-		Produces(restful.MIME_JSON)
+		Produces(restful.MIME_JSON).
+		Filter(r.AuthFilter())
 
 	logging.Log.Info("Adding v1, and API for pipelines")
 
-	wsv1.Route(wsv1.GET("/{namespace}/pipeline").To(r.getAllPipelines))
-	wsv1.Route(wsv1.GET("/{namespace}/pipeline/{name}").To(r.getPipeline))
+	nsParam := wsv1.PathParameter("namespace", "namespace of the resource").DataType("string")
+	nameParam := wsv1.PathParameter("name", "name of the resource").DataType("string")
+
+	wsv1.Route(wsv1.GET("/{namespace}/pipeline").To(r.getAllPipelines).
+		Doc("getAllPipelines").
+		Operation("getAllPipelines").
+		Param(nsParam).
+		Writes(paginatedResponse{}).
+		Returns(http.StatusOK, "OK", paginatedResponse{}).
+		Returns(http.StatusInternalServerError, "Internal Server Error", nil))
+	wsv1.Route(wsv1.GET("/{namespace}/pipeline/{name}").To(r.getPipeline).
+		Doc("getPipeline").
+		Operation("getPipeline").
+		Param(nsParam).
+		Param(nameParam).
+		Writes(pipelinev1alpha1.Pipeline{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.Pipeline{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
 
-	wsv1.Route(wsv1.GET("/{namespace}/pipelinerun").To(r.getAllPipelineRuns))
-	wsv1.Route(wsv1.GET("/{namespace}/pipelinerun/{name}").To(r.getPipelineRun))
-	wsv1.Route(wsv1.PUT("/{namespace}/pipelinerun/{name}").To(r.updatePipelineRun))
+	wsv1.Route(wsv1.GET("/{namespace}/pipelinerun").To(r.getAllPipelineRuns).
+		Doc("getAllPipelineRuns").
+		Operation("getAllPipelineRuns").
+		Param(nsParam).
+		Writes(paginatedResponse{}).
+		Returns(http.StatusOK, "OK", paginatedResponse{}).
+		Returns(http.StatusInternalServerError, "Internal Server Error", nil))
+	wsv1.Route(wsv1.GET("/{namespace}/pipelinerun/{name}").To(r.getPipelineRun).
+		Doc("getPipelineRun").
+		Operation("getPipelineRun").
+		Param(nsParam).
+		Param(nameParam).
+		Writes(pipelinev1alpha1.PipelineRun{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.PipelineRun{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
+	wsv1.Route(wsv1.PUT("/{namespace}/pipelinerun/{name}").To(r.updatePipelineRun).
+		Doc("updatePipelineRun").
+		Operation("updatePipelineRun").
+		Param(nsParam).
+		Param(nameParam).
+		Reads(pipelinev1alpha1.PipelineRun{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.PipelineRun{}).
+		Returns(http.StatusBadRequest, "Bad Request", nil))
 
-	wsv1.Route(wsv1.GET("/{namespace}/pipelineresource").To(r.getAllPipelineResources))
-	wsv1.Route(wsv1.GET("/{namespace}/pipelineresource/{name}").To(r.getPipelineResource))
+	wsv1.Route(wsv1.GET("/{namespace}/pipelineresource").To(r.getAllPipelineResources).
+		Doc("getAllPipelineResources").
+		Operation("getAllPipelineResources").
+		Param(nsParam).
+		Writes(paginatedResponse{}).
+		Returns(http.StatusOK, "OK", paginatedResponse{}))
+	wsv1.Route(wsv1.GET("/{namespace}/pipelineresource/{name}").To(r.getPipelineResource).
+		Doc("getPipelineResource").
+		Operation("getPipelineResource").
+		Param(nsParam).
+		Param(nameParam).
+		Writes(pipelinev1alpha1.PipelineResource{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.PipelineResource{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
 
-	wsv1.Route(wsv1.GET("/{namespace}/task").To(r.getAllTasks))
-	wsv1.Route(wsv1.GET("/{namespace}/task/{name}").To(r.getTask))
+	wsv1.Route(wsv1.GET("/{namespace}/task").To(r.getAllTasks).
+		Doc("getAllTasks").
+		Operation("getAllTasks").
+		Param(nsParam).
+		Writes(paginatedResponse{}).
+		Returns(http.StatusOK, "OK", paginatedResponse{}))
+	wsv1.Route(wsv1.GET("/{namespace}/task/{name}").To(r.getTask).
+		Doc("getTask").
+		Operation("getTask").
+		Param(nsParam).
+		Param(nameParam).
+		Writes(pipelinev1alpha1.Task{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.Task{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
 
-	wsv1.Route(wsv1.GET("/{namespace}/taskrun").To(r.getAllTaskRuns))
-	wsv1.Route(wsv1.GET("/{namespace}/taskrun/{name}").To(r.getTaskRun))
+	wsv1.Route(wsv1.GET("/{namespace}/taskrun").To(r.getAllTaskRuns).
+		Doc("getAllTaskRuns").
+		Operation("getAllTaskRuns").
+		Param(nsParam).
+		Writes(paginatedResponse{}).
+		Returns(http.StatusOK, "OK", paginatedResponse{}))
+	wsv1.Route(wsv1.GET("/{namespace}/taskrun/{name}").To(r.getTaskRun).
+		Doc("getTaskRun").
+		Operation("getTaskRun").
+		Param(nsParam).
+		Param(nameParam).
+		Writes(pipelinev1alpha1.TaskRun{}).
+		Returns(http.StatusOK, "OK", pipelinev1alpha1.TaskRun{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
 
-	wsv1.Route(wsv1.GET("/{namespace}/log/{name}").To(r.getPodLog))
+	wsv1.Route(wsv1.GET("/{namespace}/log/{name}").To(r.getPodLog).
+		Doc("getPodLog").
+		Operation("getPodLog").
+		Param(nsParam).
+		Param(nameParam).
+		Returns(http.StatusOK, "OK", ""))
 
-	wsv1.Route(wsv1.GET("/{namespace}/taskrunlog/{name}").To(r.getTaskRunLog))
+	wsv1.Route(wsv1.GET("/{namespace}/taskrunlog/{name}").To(r.getTaskRunLog).
+		Doc("getTaskRunLog").
+		Operation("getTaskRunLog").
+		Param(nsParam).
+		Param(nameParam).
+		Returns(http.StatusOK, "OK", ""))
 
-	wsv1.Route(wsv1.GET("/{namespace}/pipelinerunlog/{name}").To(r.getPipelineRunLog))
+	wsv1.Route(wsv1.GET("/{namespace}/pipelinerunlog/{name}").To(r.getPipelineRunLog).
+		Doc("getPipelineRunLog").
+		Operation("getPipelineRunLog").
+		Param(nsParam).
+		Param(nameParam).
+		Returns(http.StatusOK, "OK", ""))
 
-	wsv1.Route(wsv1.GET("/{namespace}/credentials/").To(r.getAllCredentials))
-	wsv1.Route(wsv1.GET("/{namespace}/credentials/{id}").To(r.getCredential))
-	wsv1.Route(wsv1.POST("/{namespace}/credentials/").To(r.createCredential))
-	wsv1.Route(wsv1.PUT("/{namespace}/credentials/{id}").To(r.updateCredential))
-	wsv1.Route(wsv1.DELETE("/{namespace}/credentials/{id}").To(r.deleteCredential))
+	wsv1.Route(wsv1.GET("/{namespace}/credentials/").To(r.getAllCredentials).
+		Doc("getAllCredentials").
+		Operation("getAllCredentials").
+		Param(nsParam).
+		Returns(http.StatusOK, "OK", CredentialList{}))
+	wsv1.Route(wsv1.GET("/{namespace}/credentials/{id}").To(r.getCredential).
+		Doc("getCredential").
+		Operation("getCredential").
+		Param(nsParam).
+		Param(wsv1.PathParameter("id", "id of the credential").DataType("string")).
+		Returns(http.StatusOK, "OK", Credential{}).
+		Returns(http.StatusNotFound, "Not Found", nil))
+	wsv1.Route(wsv1.POST("/{namespace}/credentials/").To(r.createCredential).
+		Doc("createCredential").
+		Operation("createCredential").
+		Param(nsParam).
+		Reads(Credential{}).
+		Returns(http.StatusCreated, "Created", nil))
+	wsv1.Route(wsv1.PUT("/{namespace}/credentials/{id}").To(r.updateCredential).
+		Doc("updateCredential").
+		Operation("updateCredential").
+		Param(nsParam).
+		Param(wsv1.PathParameter("id", "id of the credential").DataType("string")).
+		Reads(Credential{}).
+		Returns(http.StatusOK, "OK", nil))
+	wsv1.Route(wsv1.DELETE("/{namespace}/credentials/{id}").To(r.deleteCredential).
+		Doc("deleteCredential").
+		Operation("deleteCredential").
+		Param(nsParam).
+		Param(wsv1.PathParameter("id", "id of the credential").DataType("string")).
+		Returns(http.StatusNoContent, "No Content", nil))
 
 	container.Add(wsv1)
+
+	r.registerOpenAPI(container)
+	r.RegisterOAuthMetadata(container)
+}
+
+// registerOpenAPI - builds and serves the OpenAPI/Swagger spec for the routes
+// registered above, plus a Swagger UI under /apidocs/
+func (r Resource) registerOpenAPI(container *restful.Container) {
+	config := restfulspec.Config{
+		WebServices: container.RegisteredWebServices(),
+		APIPath:     "/swagger.json",
+		PostBuildSwaggerObjectHandler: enrichSwaggerObject,
+	}
+	container.Add(restfulspec.NewOpenAPIService(config))
+
+	logging.Log.Info("Adding API for Swagger UI")
+	docs := new(restful.WebService)
+	docs.Path("/apidocs").Produces("text/html")
+	docs.Route(docs.GET("/").To(func(request *restful.Request, response *restful.Response) {
+		response.AddHeader("Content-Type", "text/html")
+		response.Write([]byte(apidocsHTML))
+	}).
+		Doc("getAPIDocs").
+		Operation("getAPIDocs"))
+	container.Add(docs)
+}
+
+// apidocsHTML - a minimal Swagger UI page, loaded from a CDN rather than
+// vendored assets, pointed at the /swagger.json spec registered above
+const apidocsHTML = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Tekton Dashboard API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist/swagger-ui-bundle.js"></script>
+	<script>
+		window.onload = function() {
+			SwaggerUIBundle({ url: "/swagger.json", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>
+`
+
+// enrichSwaggerObject - fills in the top level info that isn't derivable
+// from route metadata alone
+func enrichSwaggerObject(swo *spec.Swagger) {
+	swo.Info = &spec.Info{
+		InfoProps: spec.InfoProps{
+			Title:       "Tekton Dashboard",
+			Description: "REST API for the Tekton Dashboard",
+			Version:     Version,
+		},
+	}
 }
 
 // RegisterWebsocket - this registers a websocket with which we can send log information to
@@ -84,7 +280,8 @@ func (r Resource) RegisterWebsocket(container *restful.Container) {
 	wsv2.
 		Path("/v1/websocket").
 		Consumes(restful.MIME_JSON).
-		Produces(restful.MIME_JSON)
+		Produces(restful.MIME_JSON).
+		Filter(r.AuthFilter())
 	wsv2.Route(wsv2.GET("/logs").To(r.establishPipelineLogsWebsocket))
 	wsv2.Route(wsv2.GET("/pipelineruns").To(r.establishPipelineRunsWebsocket))
 	container.Add(wsv2)
@@ -113,54 +310,8 @@ func (r Resource) RegisterReadinessProbes(container *restful.Container) {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	wsv4.Route(wsv4.GET("/").To(r.checkHealth))
+	wsv4.Route(wsv4.GET("/").To(r.checkReadiness))
 
 	container.Add(wsv4)
 }
 
-// Back-end extension: Requests to the URL are passthrough to the Port of the Name service (extension)
-// "label: tekton-dashboard-extension=true" in the service defines the extention
-// "annotation: tekton-dashboard-endpoints=<URL>" spacifies the path for the extension
-type Extension struct {
-	Name string
-	URL  string
-	Port string
-}
-
-// RegisterExtension - this discovers the extensions and registers them as the REST API extension 
-func (r Resource) RegisterExtension(container *restful.Container, namespace string) {
-	logging.Log.Info("Adding API for extensions")
-	svcs, err := r.K8sClient.CoreV1().Services(namespace).List(metav1.ListOptions{LabelSelector: extensionLabel})
-	if err != nil {
-		logging.Log.Errorf("no extension found: %s", err)
-		return
-	}
-	ws := new(restful.WebService)
-	ws.
-		Path("/").
-		Consumes(restful.MIME_JSON).
-		Produces(restful.MIME_JSON)
-
-	for _, svc := range svcs.Items {
-		for key, url := range svc.ObjectMeta.Annotations{
-			if key == urlKey {
-				logging.Log.Debugf("extension URL: %s", url)
-                                ext := Extension { Name: svc.ObjectMeta.Name, URL: url, Port: getPort(svc) }
-				ws.Route(ws.POST(url).To(ext.HandleExtension))
-			}
-		}
-	}
-	container.Add(ws)
-}
-
-// HandleExtension - this route request to the extention path to the extention service
-func (ext Extension) HandleExtension(request *restful.Request, response *restful.Response) {
-	target, _ := url.Parse("http://" +  ext.Name + ":" + ext.Port + "/")
-	proxy := httputil.NewSingleHostReverseProxy(target)
-	proxy.ServeHTTP(response, request.Request)
-}
-
-// getPort - this get the port of the service
-func getPort(svc corev1.Service) string {
-	return strconv.Itoa(int(svc.Spec.Ports[0].Port))
-}